@@ -0,0 +1,441 @@
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+/**
+ * DecodeHookFunc converts `data` (of dynamic type `from`) on its way into a
+ * struct field of type `to`. Returning (nil, nil) leaves `data` untouched
+ * so the decoder falls back to its built-in conversion. Hooks are meant to
+ * be composed with ComposeDecodeHooks to register conversions such as
+ * `string -> time.Duration` or `string -> url.URL`.
+ */
+type DecodeHookFunc func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error)
+
+/**
+ * ComposeDecodeHooks chains several DecodeHookFunc into one, feeding the
+ * output of each hook into the next.
+ */
+func ComposeDecodeHooks(hooks ...DecodeHookFunc) DecodeHookFunc {
+	return func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		for _, hook := range hooks {
+			out, err := hook(from, to, data)
+			if err != nil {
+				return nil, err
+			}
+			if out != nil {
+				data = out
+				from = reflect.TypeOf(out)
+			}
+		}
+		return data, nil
+	}
+}
+
+/**
+ * DecodeInto populates the struct pointed to by `out` from the normalized
+ * JSON dict `input` (as produced by CleanupJSON), using `json:"name"` for
+ * the field name and a `dcos:"name,squash,omitempty"` tag for DCOS-specific
+ * behavior: "squash" decodes `input` straight into an embedded/nested
+ * struct field instead of looking up a sub-key, and "omitempty" is honored
+ * by EncodeFrom. Nested structs, pointers, slices and string-keyed maps are
+ * supported; pass `hooks` to register custom conversions.
+ */
+func DecodeInto(input map[string]interface{}, out interface{}, hooks ...DecodeHookFunc) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("DecodeInto: out must be a non-nil pointer, got %T", out)
+	}
+
+	hook := ComposeDecodeHooks(hooks...)
+	return decodeMapInto(input, rv.Elem(), hook)
+}
+
+/**
+ * EncodeFrom is the inverse of DecodeInto: it walks the struct (or pointer
+ * to struct) `in` and produces a dict suitable for HashDict/NormalizeJSON,
+ * honoring the same `json`/`dcos` tags.
+ */
+func EncodeFrom(in interface{}) (map[string]interface{}, error) {
+	rv := reflect.ValueOf(in)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return map[string]interface{}{}, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("EncodeFrom: in must be a struct or pointer to struct, got %s", rv.Kind())
+	}
+
+	out := make(map[string]interface{})
+	if err := encodeStructInto(rv, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// parseFieldTag reads the field name and decoding options off a struct
+// field's `json` and `dcos` tags. A name in `dcos` overrides the one in
+// `json`; "omitempty" and a "-" name from either tag apply.
+func parseFieldTag(field reflect.StructField) (name string, squash bool, omitempty bool, skip bool) {
+	name = field.Name
+
+	if jsonTag, ok := field.Tag.Lookup("json"); ok {
+		parts := strings.Split(jsonTag, ",")
+		if parts[0] == "-" {
+			skip = true
+		} else if parts[0] != "" {
+			name = parts[0]
+		}
+		for _, opt := range parts[1:] {
+			if opt == "omitempty" {
+				omitempty = true
+			}
+		}
+	}
+
+	if dcosTag, ok := field.Tag.Lookup("dcos"); ok {
+		parts := strings.Split(dcosTag, ",")
+		if parts[0] == "-" {
+			skip = true
+		} else if parts[0] != "" {
+			name = parts[0]
+		}
+		for _, opt := range parts[1:] {
+			switch opt {
+			case "squash":
+				squash = true
+			case "omitempty":
+				omitempty = true
+			}
+		}
+	}
+
+	return
+}
+
+func decodeMapInto(input map[string]interface{}, target reflect.Value, hook DecodeHookFunc) error {
+	if target.Kind() != reflect.Struct {
+		return fmt.Errorf("DecodeInto: target must be a struct, got %s", target.Kind())
+	}
+
+	t := target.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+
+		name, squash, _, skip := parseFieldTag(field)
+		if skip {
+			continue
+		}
+
+		fieldValue := target.Field(i)
+
+		if squash {
+			if err := decodeValueInto(input, fieldValue, hook); err != nil {
+				return fmt.Errorf("%s: %s", field.Name, err.Error())
+			}
+			continue
+		}
+
+		raw, present := input[name]
+		if !present {
+			continue
+		}
+		if err := decodeValueInto(raw, fieldValue, hook); err != nil {
+			return fmt.Errorf("%s: %s", name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func decodeValueInto(raw interface{}, target reflect.Value, hook DecodeHookFunc) error {
+	if hook != nil && raw != nil {
+		converted, err := hook(reflect.TypeOf(raw), target.Type(), raw)
+		if err != nil {
+			return err
+		}
+		if converted != nil {
+			raw = converted
+		}
+	}
+
+	if raw == nil {
+		return nil
+	}
+
+	if target.Kind() == reflect.Ptr {
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		return decodeValueInto(raw, target.Elem(), hook)
+	}
+
+	// A hook may have already converted raw into the exact (or a
+	// convertible) target type, e.g. a "string -> url.URL" hook; prefer
+	// that over the structural decode below, which only understands the
+	// dynamic types encoding/json produces (map[string]interface{},
+	// []interface{}, and JSON scalars) and would otherwise reject a
+	// struct-shaped hook result as "not an object".
+	if !isDecodableRawShape(raw) {
+		rv := reflect.ValueOf(raw)
+		if rv.Type().AssignableTo(target.Type()) {
+			target.Set(rv)
+			return nil
+		}
+		if rv.Type().ConvertibleTo(target.Type()) {
+			target.Set(rv.Convert(target.Type()))
+			return nil
+		}
+	}
+
+	switch target.Kind() {
+	case reflect.Struct:
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected an object, got %T", raw)
+		}
+		return decodeMapInto(m, target, hook)
+
+	case reflect.Slice:
+		list, ok := raw.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected an array, got %T", raw)
+		}
+		out := reflect.MakeSlice(target.Type(), len(list), len(list))
+		for i, v := range list {
+			if err := decodeValueInto(v, out.Index(i), hook); err != nil {
+				return fmt.Errorf("[%d]: %s", i, err.Error())
+			}
+		}
+		target.Set(out)
+		return nil
+
+	case reflect.Map:
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected an object, got %T", raw)
+		}
+		if target.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type %s", target.Type().Key())
+		}
+		out := reflect.MakeMapWithSize(target.Type(), len(m))
+		elemType := target.Type().Elem()
+		for key, v := range m {
+			elemValue := reflect.New(elemType).Elem()
+			if err := decodeValueInto(v, elemValue, hook); err != nil {
+				return fmt.Errorf("%s: %s", key, err.Error())
+			}
+			out.SetMapIndex(reflect.ValueOf(key).Convert(target.Type().Key()), elemValue)
+		}
+		target.Set(out)
+		return nil
+
+	case reflect.Interface:
+		target.Set(reflect.ValueOf(raw))
+		return nil
+
+	default:
+		return decodeScalarInto(raw, target)
+	}
+}
+
+func decodeScalarInto(raw interface{}, target reflect.Value) error {
+	// A hook may already have produced the exact (or a convertible) target
+	// type, e.g. a "string -> time.Duration" hook; prefer that over the
+	// generic JSON-primitive conversions below.
+	if !isJSONPrimitive(raw) {
+		rv := reflect.ValueOf(raw)
+		if rv.Type().AssignableTo(target.Type()) {
+			target.Set(rv)
+			return nil
+		}
+		if rv.Type().ConvertibleTo(target.Type()) {
+			target.Set(rv.Convert(target.Type()))
+			return nil
+		}
+	}
+
+	switch target.Kind() {
+	case reflect.String:
+		s, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("expected a string, got %T", raw)
+		}
+		target.SetString(s)
+		return nil
+
+	case reflect.Bool:
+		b, ok := raw.(bool)
+		if !ok {
+			return fmt.Errorf("expected a boolean, got %T", raw)
+		}
+		target.SetBool(b)
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f, ok := raw.(float64)
+		if !ok {
+			return fmt.Errorf("expected a number, got %T", raw)
+		}
+		target.SetInt(int64(f))
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f, ok := raw.(float64)
+		if !ok {
+			return fmt.Errorf("expected a number, got %T", raw)
+		}
+		target.SetUint(uint64(f))
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		f, ok := raw.(float64)
+		if !ok {
+			return fmt.Errorf("expected a number, got %T", raw)
+		}
+		target.SetFloat(f)
+		return nil
+	}
+
+	return fmt.Errorf("cannot decode %T into %s", raw, target.Type())
+}
+
+// isJSONPrimitive reports whether raw is one of the dynamic types
+// encoding/json produces for a JSON scalar (string, float64, bool).
+func isJSONPrimitive(raw interface{}) bool {
+	switch raw.(type) {
+	case string, float64, bool:
+		return true
+	default:
+		return false
+	}
+}
+
+// isDecodableRawShape reports whether raw is one of the dynamic types
+// encoding/json produces (a JSON scalar, map[string]interface{}, or
+// []interface{}), i.e. something the structural decode in decodeValueInto
+// knows how to walk. Anything else is assumed to be a hook's output and is
+// assigned/converted directly instead.
+func isDecodableRawShape(raw interface{}) bool {
+	if isJSONPrimitive(raw) {
+		return true
+	}
+	switch raw.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func encodeStructInto(rv reflect.Value, out map[string]interface{}) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+
+		name, squash, omitempty, skip := parseFieldTag(field)
+		if skip {
+			continue
+		}
+
+		fieldValue := rv.Field(i)
+
+		if squash {
+			squashed := fieldValue
+			for squashed.Kind() == reflect.Ptr {
+				if squashed.IsNil() {
+					squashed = reflect.Value{}
+					break
+				}
+				squashed = squashed.Elem()
+			}
+			if !squashed.IsValid() {
+				continue
+			}
+			if squashed.Kind() != reflect.Struct {
+				return fmt.Errorf("%s: squash requires a struct field", field.Name)
+			}
+			if err := encodeStructInto(squashed, out); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if omitempty && fieldValue.IsZero() {
+			continue
+		}
+
+		encoded, err := encodeValue(fieldValue)
+		if err != nil {
+			return fmt.Errorf("%s: %s", field.Name, err.Error())
+		}
+		if encoded == nil && omitempty {
+			continue
+		}
+		out[name] = encoded
+	}
+	return nil
+}
+
+func encodeValue(v reflect.Value) (interface{}, error) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		out := make(map[string]interface{})
+		if err := encodeStructInto(v, out); err != nil {
+			return nil, err
+		}
+		return out, nil
+
+	case reflect.Slice, reflect.Array:
+		out := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			encoded, err := encodeValue(v.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %s", i, err.Error())
+			}
+			out[i] = encoded
+		}
+		return out, nil
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", v.Type().Key())
+		}
+		out := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			encoded, err := encodeValue(v.MapIndex(key))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", key.String(), err.Error())
+			}
+			out[key.String()] = encoded
+		}
+		return out, nil
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return encodeValue(v.Elem())
+
+	default:
+		return v.Interface(), nil
+	}
+}
@@ -0,0 +1,123 @@
+package util
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type DecodeTestBase struct {
+	Name string `json:"name"`
+}
+
+type decodeTestOptions struct {
+	DecodeTestBase `dcos:",squash"`
+	Port           int               `json:"port"`
+	Labels         []string          `json:"labels"`
+	Env            map[string]string `json:"env"`
+	Timeout        time.Duration     `json:"timeout"`
+	Optional       *string           `json:"optional,omitempty" dcos:",omitempty"`
+}
+
+func durationDecodeHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if to == reflect.TypeOf(time.Duration(0)) {
+		if s, ok := data.(string); ok {
+			return time.ParseDuration(s)
+		}
+	}
+	return nil, nil
+}
+
+func TestDecodeIntoAndEncodeFromRoundTrip(t *testing.T) {
+	raw := `{"name":"svc","port":8080,"labels":["a","b"],"env":{"FOO":"bar"},"timeout":"5s"}`
+	var input map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &input); err != nil {
+		t.Fatalf("invalid test fixture: %s", err.Error())
+	}
+
+	var opts decodeTestOptions
+	if err := DecodeInto(input, &opts, durationDecodeHook); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	want := decodeTestOptions{
+		DecodeTestBase: DecodeTestBase{Name: "svc"},
+		Port:           8080,
+		Labels:         []string{"a", "b"},
+		Env:            map[string]string{"FOO": "bar"},
+		Timeout:        5 * time.Second,
+	}
+	if !reflect.DeepEqual(opts, want) {
+		t.Fatalf("got %#v, want %#v", opts, want)
+	}
+
+	encoded, err := EncodeFrom(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if encoded["name"] != "svc" {
+		t.Fatalf("squashed field not encoded back: %#v", encoded)
+	}
+	if _, hasOptional := encoded["optional"]; hasOptional {
+		t.Fatalf("omitempty field should be dropped: %#v", encoded)
+	}
+}
+
+func TestDecodeIntoHookProducingStructValue(t *testing.T) {
+	// Regression test: a DecodeHookFunc that converts a string into a
+	// struct-kind value (e.g. "string -> url.URL") must not be rejected by
+	// the object-shaped decode path.
+	type config struct {
+		Endpoint url.URL `json:"endpoint"`
+	}
+
+	urlHook := func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		if to == reflect.TypeOf(url.URL{}) {
+			if s, ok := data.(string); ok {
+				u, err := url.Parse(s)
+				if err != nil {
+					return nil, err
+				}
+				return *u, nil
+			}
+		}
+		return nil, nil
+	}
+
+	input := map[string]interface{}{"endpoint": "https://example.com/path"}
+
+	var cfg config
+	if err := DecodeInto(input, &cfg, urlHook); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if cfg.Endpoint.String() != "https://example.com/path" {
+		t.Fatalf("got %q, want %q", cfg.Endpoint.String(), "https://example.com/path")
+	}
+}
+
+func TestDecodeIntoPointerField(t *testing.T) {
+	type config struct {
+		Name *string `json:"name"`
+	}
+
+	input := map[string]interface{}{"name": "set"}
+	var cfg config
+	if err := DecodeInto(input, &cfg); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if cfg.Name == nil || *cfg.Name != "set" {
+		t.Fatalf("got %#v, want Name = \"set\"", cfg)
+	}
+}
+
+func TestDecodeIntoRejectsNonPointer(t *testing.T) {
+	type config struct{}
+
+	if err := DecodeInto(map[string]interface{}{}, config{}); err == nil {
+		t.Fatal("expected an error when out is not a pointer")
+	}
+}
@@ -0,0 +1,277 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/**
+ * PatchOp is a single RFC 6902 JSON Patch operation. Path is a RFC 6901
+ * JSON Pointer ("/" separated, with "~" and "/" escaped as "~0"/"~1").
+ */
+type PatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+/**
+ * GetJSONPatch compares `reference` against `input` and returns the RFC 6902
+ * JSON Patch operations required to turn `reference` into `input`. Unlike
+ * GetDictDiff, which collapses changes into a nested map and loses "deleted
+ * key" information, the resulting patch can be sent as-is to APIs (such as
+ * the DCOS Cosmos/Marathon ones) that natively accept JSON Patch bodies.
+ */
+func GetJSONPatch(reference map[string]interface{}, input map[string]interface{}) ([]PatchOp, error) {
+	var ops []PatchOp
+	diffToPatch("", reference, input, &ops)
+	return ops, nil
+}
+
+/**
+ * MarshalJSONPatch encodes a list of PatchOp as the JSON array expected by
+ * RFC 6902 consumers.
+ */
+func MarshalJSONPatch(ops []PatchOp) ([]byte, error) {
+	return json.Marshal(ops)
+}
+
+/**
+ * ApplyJSONPatch applies `ops` to `doc` in order and returns the resulting
+ * document. `doc` itself is left untouched.
+ */
+func ApplyJSONPatch(doc map[string]interface{}, ops []PatchOp) (map[string]interface{}, error) {
+	var root interface{} = doc
+	for _, op := range ops {
+		var err error
+		root, err = applyPatchOp(root, op)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %s", op.Op, op.Path, err.Error())
+		}
+	}
+
+	result, ok := root.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("patched document is no longer an object")
+	}
+	return result, nil
+}
+
+// diffToPatch walks reference and input in lock-step, appending the PatchOp
+// values needed to turn reference into input at ops.
+func diffToPatch(path string, reference, input interface{}, ops *[]PatchOp) {
+	if reference == nil && input == nil {
+		return
+	}
+	if reference == nil {
+		*ops = append(*ops, PatchOp{Op: "add", Path: path, Value: input})
+		return
+	}
+	if input == nil {
+		*ops = append(*ops, PatchOp{Op: "remove", Path: path})
+		return
+	}
+
+	if reflect.TypeOf(reference) != reflect.TypeOf(input) {
+		*ops = append(*ops, PatchOp{Op: "replace", Path: path, Value: input})
+		return
+	}
+
+	switch ref := reference.(type) {
+	case map[string]interface{}:
+		in := input.(map[string]interface{})
+
+		for key, value := range in {
+			childPath := path + "/" + escapeJSONPointerToken(key)
+			if refValue, ok := ref[key]; ok {
+				diffToPatch(childPath, refValue, value, ops)
+			} else {
+				*ops = append(*ops, PatchOp{Op: "add", Path: childPath, Value: value})
+			}
+		}
+		for key := range ref {
+			if _, ok := in[key]; !ok {
+				*ops = append(*ops, PatchOp{Op: "remove", Path: path + "/" + escapeJSONPointerToken(key)})
+			}
+		}
+
+	case []interface{}:
+		in := input.([]interface{})
+
+		common := len(ref)
+		if len(in) < common {
+			common = len(in)
+		}
+		for i := 0; i < common; i++ {
+			diffToPatch(fmt.Sprintf("%s/%d", path, i), ref[i], in[i], ops)
+		}
+		for i := len(ref); i < len(in); i++ {
+			*ops = append(*ops, PatchOp{Op: "add", Path: fmt.Sprintf("%s/%d", path, i), Value: in[i]})
+		}
+		// Remove trailing elements back-to-front so earlier indices stay
+		// valid while the patch is applied in order.
+		for i := len(ref) - 1; i >= len(in); i-- {
+			*ops = append(*ops, PatchOp{Op: "remove", Path: fmt.Sprintf("%s/%d", path, i)})
+		}
+
+	default:
+		if reference != input {
+			*ops = append(*ops, PatchOp{Op: "replace", Path: path, Value: input})
+		}
+	}
+}
+
+// applyPatchOp applies a single PatchOp to root and returns the new root.
+func applyPatchOp(root interface{}, op PatchOp) (interface{}, error) {
+	if op.Path == "" {
+		switch op.Op {
+		case "add", "replace":
+			return op.Value, nil
+		case "remove":
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("unsupported operation %q", op.Op)
+		}
+	}
+
+	tokens := splitJSONPointer(op.Path)
+	parentTokens, lastToken := tokens[:len(tokens)-1], tokens[len(tokens)-1]
+
+	return mutateAt(root, parentTokens, func(container interface{}) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			out := shallowCopySchemaNode(c)
+			switch op.Op {
+			case "add", "replace":
+				out[lastToken] = op.Value
+			case "remove":
+				delete(out, lastToken)
+			default:
+				return nil, fmt.Errorf("unsupported operation %q", op.Op)
+			}
+			return out, nil
+
+		case []interface{}:
+			idx, isAppend, err := parseArrayIndex(lastToken, len(c))
+			if err != nil {
+				return nil, err
+			}
+			switch op.Op {
+			case "add":
+				out := make([]interface{}, 0, len(c)+1)
+				if isAppend {
+					out = append(out, c...)
+					out = append(out, op.Value)
+				} else {
+					if idx < 0 || idx > len(c) {
+						return nil, fmt.Errorf("index %d out of range", idx)
+					}
+					out = append(out, c[:idx]...)
+					out = append(out, op.Value)
+					out = append(out, c[idx:]...)
+				}
+				return out, nil
+
+			case "replace":
+				if idx < 0 || idx >= len(c) {
+					return nil, fmt.Errorf("index %d out of range", idx)
+				}
+				out := append([]interface{}(nil), c...)
+				out[idx] = op.Value
+				return out, nil
+
+			case "remove":
+				if idx < 0 || idx >= len(c) {
+					return nil, fmt.Errorf("index %d out of range", idx)
+				}
+				out := make([]interface{}, 0, len(c)-1)
+				out = append(out, c[:idx]...)
+				out = append(out, c[idx+1:]...)
+				return out, nil
+
+			default:
+				return nil, fmt.Errorf("unsupported operation %q", op.Op)
+			}
+
+		default:
+			return nil, fmt.Errorf("does not resolve to a container")
+		}
+	})
+}
+
+// mutateAt walks `tokens` into `node` and calls `mutate` on the container it
+// resolves to, rebuilding the path back up to `node` with the mutated
+// result (maps and slices are never mutated in place, so that callers
+// holding onto `node` see it unchanged).
+func mutateAt(node interface{}, tokens []string, mutate func(interface{}) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 0 {
+		return mutate(node)
+	}
+
+	token := tokens[0]
+	rest := tokens[1:]
+
+	switch v := node.(type) {
+	case map[string]interface{}:
+		child, ok := v[token]
+		if !ok {
+			return nil, fmt.Errorf("path segment %q not found", token)
+		}
+		newChild, err := mutateAt(child, rest, mutate)
+		if err != nil {
+			return nil, err
+		}
+		out := shallowCopySchemaNode(v)
+		out[token] = newChild
+		return out, nil
+
+	case []interface{}:
+		idx, _, err := parseArrayIndex(token, len(v))
+		if err != nil {
+			return nil, err
+		}
+		if idx < 0 || idx >= len(v) {
+			return nil, fmt.Errorf("index %d out of range", idx)
+		}
+		newChild, err := mutateAt(v[idx], rest, mutate)
+		if err != nil {
+			return nil, err
+		}
+		out := append([]interface{}(nil), v...)
+		out[idx] = newChild
+		return out, nil
+
+	default:
+		return nil, fmt.Errorf("path segment %q does not resolve to a container", token)
+	}
+}
+
+// splitJSONPointer splits a RFC 6901 JSON Pointer into its unescaped tokens.
+func splitJSONPointer(path string) []string {
+	trimmed := strings.TrimPrefix(path, "/")
+	if trimmed == "" {
+		return []string{}
+	}
+	parts := strings.Split(trimmed, "/")
+	tokens := make([]string, len(parts))
+	for i, p := range parts {
+		tokens[i] = unescapeJSONPointerToken(p)
+	}
+	return tokens
+}
+
+// parseArrayIndex parses a JSON Pointer array token, recognizing the RFC
+// 6901 "-" token as "append after the last element".
+func parseArrayIndex(token string, length int) (int, bool, error) {
+	if token == "-" {
+		return length, true, nil
+	}
+	idx, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid array index %q", token)
+	}
+	return idx, false, nil
+}
@@ -0,0 +1,104 @@
+package util
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestGetJSONPatchAndApplyRoundTrip(t *testing.T) {
+	reference := map[string]interface{}{
+		"name":    "a",
+		"removed": "x",
+		"labels":  []interface{}{"a", "b", "c"},
+		"nested":  map[string]interface{}{"x": float64(1)},
+	}
+	input := map[string]interface{}{
+		"name":   "b",
+		"added":  "y",
+		"labels": []interface{}{"a", "b"},
+		"nested": map[string]interface{}{"x": float64(2)},
+	}
+
+	ops, err := GetJSONPatch(reference, input)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	applied, err := ApplyJSONPatch(reference, ops)
+	if err != nil {
+		t.Fatalf("unexpected error applying patch: %s", err.Error())
+	}
+
+	if !reflect.DeepEqual(applied, input) {
+		t.Fatalf("got %#v, want %#v", applied, input)
+	}
+
+	// The reference map passed in must not have been mutated.
+	if reference["name"] != "a" {
+		t.Fatalf("ApplyJSONPatch mutated its input: reference[name] = %v", reference["name"])
+	}
+}
+
+func TestGetJSONPatchArrayShrinkRemovesFromTheEnd(t *testing.T) {
+	reference := map[string]interface{}{
+		"labels": []interface{}{"a", "b", "c"},
+	}
+	input := map[string]interface{}{
+		"labels": []interface{}{"a", "b"},
+	}
+
+	ops, err := GetJSONPatch(reference, input)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	want := []PatchOp{{Op: "remove", Path: "/labels/2"}}
+	if !reflect.DeepEqual(ops, want) {
+		t.Fatalf("got %#v, want %#v", ops, want)
+	}
+}
+
+func TestMarshalJSONPatchProducesRFC6902Shape(t *testing.T) {
+	ops := []PatchOp{
+		{Op: "add", Path: "/a/b", Value: "v"},
+		{Op: "remove", Path: "/c"},
+	}
+
+	raw, err := MarshalJSONPatch(ops)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if decoded[0]["op"] != "add" || decoded[0]["path"] != "/a/b" || decoded[0]["value"] != "v" {
+		t.Fatalf("unexpected add op: %#v", decoded[0])
+	}
+	if decoded[1]["op"] != "remove" || decoded[1]["path"] != "/c" {
+		t.Fatalf("unexpected remove op: %#v", decoded[1])
+	}
+	if _, hasValue := decoded[1]["value"]; hasValue {
+		t.Fatalf("remove op should omit \"value\": %#v", decoded[1])
+	}
+}
+
+func TestApplyJSONPatchEscapedPath(t *testing.T) {
+	doc := map[string]interface{}{
+		"a/b": "old",
+	}
+
+	ops := []PatchOp{{Op: "replace", Path: "/a~1b", Value: "new"}}
+
+	applied, err := ApplyJSONPatch(doc, ops)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if applied["a/b"] != "new" {
+		t.Fatalf("got %#v, want a/b = new", applied)
+	}
+}
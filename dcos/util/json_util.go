@@ -2,10 +2,8 @@ package util
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"reflect"
 	"strconv"
 )
 
@@ -55,107 +53,14 @@ func NormalizeJSON(inputJson string) (string, error) {
  * Normalizes and hashes
  */
 func HashDict(input map[string]interface{}) (string, error) {
-	// JSON serializer serializes the keys in alphabetical order, so we
-	// are certain that every time the result will be the same
-	bytes, err := json.Marshal(CleanupJSON(input))
-	if err != nil {
-		return "", err
-	}
-
-	sum := sha256.Sum256(bytes)
-	return fmt.Sprintf("%x", sum), nil
+	return HashDictWithHints(input, nil)
 }
 
 /**
  * GetDictDiff Returns a map with all the different keys in `input`, compared to `reference`
  */
 func GetDictDiff(reference map[string]interface{}, input map[string]interface{}) map[string]interface{} {
-	ret := make(map[string]interface{})
-	for k, v := range input {
-		if rv, ok := reference[k]; ok {
-			replace, nv := getValueDiff(rv, v)
-			if replace {
-				ret[k] = nv
-			}
-		} else {
-			// If the value does not exist in reference, it's new, and we
-			// should include it.
-			ret[k] = v
-		}
-	}
-
-	return ret
-}
-
-/**
- * getValueDiff compares a reference and an input value and checks if the input value
- * should be included in the diff or not
- */
-func getValueDiff(reference interface{}, input interface{}) (bool, interface{}) {
-	// Type change always indicates a replacement
-	if reflect.TypeOf(reference) != reflect.TypeOf(input) {
-		return true, input
-	}
-
-	// Otherwise, replacement depends on the underlying type
-	switch v := reference.(type) {
-	case map[string]interface{}:
-		// Maps are compared element-wise
-		diff := GetDictDiff(v, input.(map[string]interface{}))
-		if len(diff) == 0 {
-			return false, nil
-		}
-		return true, diff
-
-	case []interface{}:
-		// Arrays are compared against their content match
-		ia := input.([]interface{})
-		if len(v) != len(ia) {
-			return true, input
-		}
-		isEqual := true
-		for i, iv := range v {
-			if iv != ia[i] {
-				isEqual = false
-				break
-			}
-		}
-		if !isEqual {
-			return true, input
-		}
-
-	default:
-		// Dynamic types are compared according to their dynamic value
-		if v != input {
-			return true, input
-		}
-	}
-
-	// By default do not include this item
-	return false, nil
-}
-
-/**
- * Processes the given JSON schema and extracts the default values into a
- * configuration JSON object
- */
-func DefaultJSONFromSchema(inputSchema map[string]interface{}) (map[string]map[string]interface{}, error) {
-	defaultValue, err := defaultFromSchemaObject(inputSchema)
-	if err != nil {
-		return nil, err
-	}
-
-	// Convert to nested map interface, as required
-	result := make(map[string]map[string]interface{})
-	for key, value := range defaultValue {
-		if mapValue, ok := interface{}(value).(map[string]interface{}); ok {
-			result[key] = mapValue
-		} else {
-			return nil, fmt.Errorf("%s: Expecting a map", key)
-		}
-	}
-
-	return result, nil
+	return GetDictDiffWithHints(reference, input, nil)
 }
 
 /**
@@ -226,7 +131,11 @@ func CleanupJSON(input interface{}) interface{} {
 }
 
 /**
- * Best-effort auto-typing of strings that follow the given patterns:
+ * Deprecated: best-effort auto-typing of strings that follow the given
+ * patterns. Guessing misfires on values like version strings ("1.10") or
+ * the literal strings "true"/"null" that must remain strings; prefer
+ * CoerceToSchema, which converts according to a JSON Schema node instead.
+ * Kept as a fallback for call sites with no schema available.
  *
  * 1) Numeric values --> float64
  * 2) "true" / "false" --> bool
@@ -252,7 +161,9 @@ func AutotypeValue(input interface{}) interface{} {
 }
 
 /**
- * Processes the values of the given map and tries some best-effort type-casting
+ * Deprecated: processes the values of the given map and tries some
+ * best-effort type-casting. Prefer CoerceMapToSchema when a schema is
+ * available.
  */
 func AutotypeMap(input map[string]interface{}) map[string]interface{} {
 	ret := make(map[string]interface{})
@@ -264,7 +175,9 @@ func AutotypeMap(input map[string]interface{}) map[string]interface{} {
 }
 
 /**
- * Processes the values of the given slice and tries some best-effort type-casting
+ * Deprecated: processes the values of the given slice and tries some
+ * best-effort type-casting. Prefer CoerceToSchema with an `items` schema
+ * when one is available.
  */
 func AutotypeList(input []interface{}) []interface{} {
 	var ret []interface{} = nil
@@ -275,70 +188,3 @@ func AutotypeList(input []interface{}) []interface{} {
 	return ret
 }
 
-/**
- * Gets or guesses a schema node type
- */
-func getSchemaNodeType(input map[string]interface{}) string {
-	if varType, ok := input["type"]; ok {
-		return varType.(string)
-	}
-
-	// Guess
-	if _, ok := input["properties"]; ok {
-		return "object"
-	}
-
-	// Default to 'string'
-	return "string"
-}
-
-/**
- * Walk a {type: "object"} schema entry and return a map with the default values
- */
-func defaultFromSchemaObject(input map[string]interface{}) (map[string]interface{}, error) {
-	result := make(map[string]interface{})
-	varType := getSchemaNodeType(input)
-	if varType != "object" {
-		return nil, fmt.Errorf("Trying to process a non-object as object")
-	}
-
-	props, ok := input["properties"]
-	if !ok {
-		return result, nil
-	}
-
-	for key, value := range props.(map[string]interface{}) {
-		if valueMap, ok := value.(map[string]interface{}); ok {
-			defaultValue, err := defaultFromSchemaValue(valueMap)
-			if err != nil {
-				return nil, fmt.Errorf("%s: %s", key, err.Error())
-			}
-			if defaultValue != nil {
-				result[key] = defaultValue
-			}
-		}
-	}
-
-	return result, nil
-}
-
-/**
- * Walk a {type: "*"} schema entry with a default value and return it.
- * Otherwise returns `nil` if a default value is missing
- */
-func defaultFromSchemaValue(input map[string]interface{}) (interface{}, error) {
-	varType := getSchemaNodeType(input)
-
-	// Objects require some nesting
-	if varType == "object" {
-		return defaultFromSchemaObject(input)
-	}
-
-	// Otherwise, get the "default" field, if any
-	defaultValue, ok := input["default"]
-	if !ok {
-		return nil, nil
-	}
-
-	return defaultValue, nil
-}
@@ -0,0 +1,23 @@
+package util
+
+import "strings"
+
+/**
+ * Escapes a single JSON-Pointer reference token per RFC 6901: "~" becomes
+ * "~0" and "/" becomes "~1". The "~" escape must run first, otherwise it
+ * would also escape the "~" just introduced by the "/" replacement.
+ */
+func escapeJSONPointerToken(token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	token = strings.ReplaceAll(token, "/", "~1")
+	return token
+}
+
+/**
+ * Reverses escapeJSONPointerToken
+ */
+func unescapeJSONPointerToken(token string) string {
+	token = strings.ReplaceAll(token, "~1", "/")
+	token = strings.ReplaceAll(token, "~0", "~")
+	return token
+}
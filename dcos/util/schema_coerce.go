@@ -0,0 +1,249 @@
+package util
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/**
+ * CoerceToSchema converts `value` according to what the JSON Schema node
+ * `schema` says about it, instead of guessing from the string content the
+ * way the deprecated AutotypeValue family does. This matters for DCOS
+ * options like version strings ("1.10"), zero-padded IDs, or the literal
+ * strings "true"/"null" that must remain strings rather than being
+ * reinterpreted as a different type.
+ */
+func CoerceToSchema(value interface{}, schema map[string]interface{}) (interface{}, error) {
+	coerced, err := coerceByType(value, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		return coerceEnum(coerced, enum)
+	}
+
+	return coerced, nil
+}
+
+func coerceByType(value interface{}, schema map[string]interface{}) (interface{}, error) {
+	switch getSchemaNodeType(schema) {
+	case "integer":
+		return coerceInteger(value, schema)
+	case "number":
+		return coerceNumber(value, schema)
+	case "boolean":
+		return coerceBoolean(value)
+	case "array":
+		return coerceArray(value, schema)
+	case "object":
+		return coerceObject(value, schema)
+	default:
+		// "string" and anything else pass through unchanged
+		return value, nil
+	}
+}
+
+/**
+ * CoerceMapToSchema applies CoerceToSchema to every property of `input`
+ * described by `schema`'s top-level "properties".
+ */
+func CoerceMapToSchema(input map[string]interface{}, schema map[string]interface{}) (map[string]interface{}, error) {
+	result, err := coerceObject(input, schema)
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]interface{}), nil
+}
+
+func coerceEnum(value interface{}, enum []interface{}) (interface{}, error) {
+	if strValue, ok := value.(string); ok {
+		for _, candidate := range enum {
+			if candidateStr, ok := candidate.(string); ok && candidateStr == strValue {
+				return candidate, nil
+			}
+		}
+	} else if valueFloat, ok := asFloat64(value); ok {
+		// Enum candidates come straight out of encoding/json, so they are
+		// always float64 even when the schema's "type" is "integer"; compare
+		// numerically rather than by Go type so a coerced int64 still matches.
+		for _, candidate := range enum {
+			if candidateFloat, ok := asFloat64(candidate); ok && candidateFloat == valueFloat {
+				return value, nil
+			}
+		}
+	} else {
+		for _, candidate := range enum {
+			if reflect.DeepEqual(candidate, value) {
+				return candidate, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%v is not one of the allowed values: %s", value, joinEnumChoices(enum))
+}
+
+func asFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func joinEnumChoices(enum []interface{}) string {
+	choices := make([]string, len(enum))
+	for i, v := range enum {
+		choices[i] = fmt.Sprint(v)
+	}
+	return strings.Join(choices, ", ")
+}
+
+func coerceInteger(value interface{}, schema map[string]interface{}) (interface{}, error) {
+	var result int64
+
+	switch v := value.(type) {
+	case string:
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid integer: %s", v, err.Error())
+		}
+		result = parsed
+	case float64:
+		if v != math.Trunc(v) {
+			return nil, fmt.Errorf("%v is not a valid integer", v)
+		}
+		if v < math.MinInt64 || v >= math.MaxInt64 {
+			return nil, fmt.Errorf("%v overflows a 64-bit integer", v)
+		}
+		result = int64(v)
+	case int:
+		result = int64(v)
+	case int64:
+		result = v
+	default:
+		return nil, fmt.Errorf("cannot coerce %T to integer", value)
+	}
+
+	if err := checkNumericRange(float64(result), schema); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func coerceNumber(value interface{}, schema map[string]interface{}) (interface{}, error) {
+	var result float64
+
+	switch v := value.(type) {
+	case string:
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid number: %s", v, err.Error())
+		}
+		result = parsed
+	case float64:
+		result = v
+	case int:
+		result = float64(v)
+	case int64:
+		result = float64(v)
+	default:
+		return nil, fmt.Errorf("cannot coerce %T to number", value)
+	}
+
+	if err := checkNumericRange(result, schema); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func checkNumericRange(value float64, schema map[string]interface{}) error {
+	if min, ok := schema["minimum"].(float64); ok && value < min {
+		return fmt.Errorf("%v is less than the minimum of %v", value, min)
+	}
+	if max, ok := schema["maximum"].(float64); ok && value > max {
+		return fmt.Errorf("%v is greater than the maximum of %v", value, max)
+	}
+	return nil
+}
+
+func coerceBoolean(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		switch v {
+		case "true", "1":
+			return true, nil
+		case "false", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%q is not a valid boolean", v)
+	case float64:
+		switch v {
+		case 1:
+			return true, nil
+		case 0:
+			return false, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot coerce %T to boolean", value)
+}
+
+func coerceArray(value interface{}, schema map[string]interface{}) (interface{}, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot coerce %T to array", value)
+	}
+
+	items, _ := schema["items"].(map[string]interface{})
+	result := make([]interface{}, len(list))
+	for i, v := range list {
+		if items == nil {
+			result[i] = v
+			continue
+		}
+		coerced, err := CoerceToSchema(v, items)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %s", i, err.Error())
+		}
+		result[i] = coerced
+	}
+
+	return result, nil
+}
+
+func coerceObject(value interface{}, schema map[string]interface{}) (interface{}, error) {
+	dict, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot coerce %T to object", value)
+	}
+
+	props, _ := schema["properties"].(map[string]interface{})
+	result := make(map[string]interface{}, len(dict))
+	for key, v := range dict {
+		propSchema, ok := props[key].(map[string]interface{})
+		if !ok {
+			result[key] = v
+			continue
+		}
+		coerced, err := CoerceToSchema(v, propSchema)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", key, err.Error())
+		}
+		result[key] = coerced
+	}
+
+	return result, nil
+}
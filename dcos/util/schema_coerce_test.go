@@ -0,0 +1,125 @@
+package util
+
+import "testing"
+
+func TestCoerceToSchemaEnumMatchesCoercedValue(t *testing.T) {
+	schema := mustSchema(t, `{"type": "integer", "enum": [1, 2, 3]}`)
+
+	got, err := CoerceToSchema("2", schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if got != int64(2) {
+		t.Fatalf("got %#v, want int64(2)", got)
+	}
+}
+
+func TestCoerceToSchemaEnumRejectsValueOutsideAllowedSet(t *testing.T) {
+	schema := mustSchema(t, `{"type": "integer", "enum": [1, 2, 3]}`)
+
+	if _, err := CoerceToSchema("5", schema); err == nil {
+		t.Fatal("expected an error for a value outside the enum")
+	}
+}
+
+func TestCoerceIntegerRejectsOverflow(t *testing.T) {
+	schema := mustSchema(t, `{"type": "integer"}`)
+
+	if _, err := CoerceToSchema(1e20, schema); err == nil {
+		t.Fatal("expected an error for a float that overflows int64")
+	}
+}
+
+func TestCoerceIntegerRejectsNonIntegralFloat(t *testing.T) {
+	schema := mustSchema(t, `{"type": "integer"}`)
+
+	if _, err := CoerceToSchema(1.5, schema); err == nil {
+		t.Fatal("expected an error for a non-integral float")
+	}
+}
+
+func TestCoerceIntegerFromString(t *testing.T) {
+	schema := mustSchema(t, `{"type": "integer", "minimum": 0, "maximum": 10}`)
+
+	got, err := CoerceToSchema("7", schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if got != int64(7) {
+		t.Fatalf("got %#v, want int64(7)", got)
+	}
+
+	if _, err := CoerceToSchema("11", schema); err == nil {
+		t.Fatal("expected an error for a value above the maximum")
+	}
+}
+
+func TestCoerceNumberFromString(t *testing.T) {
+	schema := mustSchema(t, `{"type": "number"}`)
+
+	got, err := CoerceToSchema("3.25", schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if got != 3.25 {
+		t.Fatalf("got %#v, want 3.25", got)
+	}
+}
+
+func TestCoerceBoolean(t *testing.T) {
+	schema := mustSchema(t, `{"type": "boolean"}`)
+
+	for _, tc := range []struct {
+		input interface{}
+		want  bool
+	}{
+		{"true", true},
+		{"false", false},
+		{"1", true},
+		{"0", false},
+		{float64(1), true},
+		{float64(0), false},
+		{true, true},
+	} {
+		got, err := CoerceToSchema(tc.input, schema)
+		if err != nil {
+			t.Fatalf("unexpected error coercing %#v: %s", tc.input, err.Error())
+		}
+		if got != tc.want {
+			t.Fatalf("coercing %#v: got %#v, want %#v", tc.input, got, tc.want)
+		}
+	}
+
+	if _, err := CoerceToSchema("nope", schema); err == nil {
+		t.Fatal("expected an error for an invalid boolean string")
+	}
+}
+
+func TestCoerceMapToSchemaAppliesPerPropertyCoercion(t *testing.T) {
+	schema := mustSchema(t, `{
+		"type": "object",
+		"properties": {
+			"port": {"type": "integer"},
+			"enabled": {"type": "boolean"}
+		}
+	}`)
+
+	result, err := CoerceMapToSchema(map[string]interface{}{
+		"port":    "8080",
+		"enabled": "true",
+		"extra":   "untouched",
+	}, schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if result["port"] != int64(8080) {
+		t.Fatalf("got port %#v, want int64(8080)", result["port"])
+	}
+	if result["enabled"] != true {
+		t.Fatalf("got enabled %#v, want true", result["enabled"])
+	}
+	if result["extra"] != "untouched" {
+		t.Fatalf("got extra %#v, want it to pass through unchanged", result["extra"])
+	}
+}
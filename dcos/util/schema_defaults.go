@@ -0,0 +1,426 @@
+package util
+
+import "fmt"
+
+/**
+ * SchemaDefaults is the result of walking a JSON Schema object node for
+ * default values: the defaults themselves, plus the set of property names
+ * the schema (after merging in any "allOf" branches) marks as required, so
+ * that callers can drive validation without re-walking the schema.
+ */
+type SchemaDefaults struct {
+	Defaults map[string]interface{}
+	Required map[string]bool
+}
+
+/**
+ * Processes the given JSON schema and extracts the default values into a
+ * configuration JSON object. Local "$ref" pointers, "allOf" composition,
+ * "oneOf"/"anyOf" branches and array defaults are all honored; see
+ * DefaultJSONFromSchemaWithRefs if the schema also contains cross-file refs,
+ * or DefaultJSONFromSchemaWithRequired to also get at each section's
+ * required fields.
+ */
+func DefaultJSONFromSchema(inputSchema map[string]interface{}) (map[string]map[string]interface{}, error) {
+	defaults, _, err := DefaultJSONFromSchemaWithRequired(inputSchema, nil)
+	return defaults, err
+}
+
+/**
+ * DefaultJSONFromSchemaWithRefs behaves like DefaultJSONFromSchema, but
+ * additionally resolves cross-file "$ref" pointers (e.g. "common.json#/definitions/Foo")
+ * through `loader`. Pass a nil loader if the schema only contains local
+ * "#/..." refs.
+ */
+func DefaultJSONFromSchemaWithRefs(inputSchema map[string]interface{}, loader SchemaRefLoader) (map[string]map[string]interface{}, error) {
+	defaults, _, err := DefaultJSONFromSchemaWithRequired(inputSchema, loader)
+	return defaults, err
+}
+
+/**
+ * DefaultJSONFromSchemaWithRequired behaves like DefaultJSONFromSchemaWithRefs,
+ * but additionally returns, for each top-level section, the set of
+ * property names that section's schema (after "allOf" merging) marks as
+ * required, so that callers can drive validation without re-walking the
+ * schema themselves.
+ */
+func DefaultJSONFromSchemaWithRequired(inputSchema map[string]interface{}, loader SchemaRefLoader) (map[string]map[string]interface{}, map[string]map[string]bool, error) {
+	resolver := newSchemaResolver(inputSchema, loader)
+
+	defaults, err := defaultFromSchemaObject(resolver, inputSchema)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Convert to nested map interface, as required
+	result := make(map[string]map[string]interface{})
+	for key, value := range defaults.Defaults {
+		if mapValue, ok := interface{}(value).(map[string]interface{}); ok {
+			result[key] = mapValue
+		} else {
+			return nil, nil, fmt.Errorf("%s: Expecting a map", key)
+		}
+	}
+
+	root, err := resolveSchemaNode(resolver, inputSchema)
+	if err != nil {
+		return nil, nil, err
+	}
+	root, err = mergeAllOf(resolver, root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	required := make(map[string]map[string]bool)
+	if props, ok := root["properties"].(map[string]interface{}); ok {
+		for key, value := range props {
+			propSchema, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			nested, err := defaultFromSchemaObject(resolver, propSchema)
+			if err != nil {
+				// Not every section necessarily resolves to an object (e.g.
+				// a string-typed section with no default); skip it rather
+				// than failing the whole call.
+				continue
+			}
+			required[key] = nested.Required
+		}
+	}
+
+	return result, required, nil
+}
+
+/**
+ * Gets or guesses a schema node type
+ */
+func getSchemaNodeType(input map[string]interface{}) string {
+	if varType, ok := input["type"].(string); ok {
+		return varType
+	}
+
+	// Guess
+	if _, ok := input["properties"]; ok {
+		return "object"
+	}
+	if _, ok := input["items"]; ok {
+		return "array"
+	}
+
+	// Default to 'string'
+	return "string"
+}
+
+// resolveSchemaNode follows a single "$ref", if present, otherwise returns
+// the node unchanged.
+func resolveSchemaNode(resolver *schemaResolver, node map[string]interface{}) (map[string]interface{}, error) {
+	if ref, ok := node["$ref"].(string); ok {
+		return resolver.resolve(ref)
+	}
+	return node, nil
+}
+
+// mergeAllOf resolves and deep-merges an "allOf" list of subschemas into the
+// node they are attached to. "properties" are unioned (the node's own
+// properties win over an allOf branch's), "required" lists are unioned, and
+// any other sibling keyword present on the node takes precedence over the
+// same keyword coming from a branch.
+func mergeAllOf(resolver *schemaResolver, node map[string]interface{}) (map[string]interface{}, error) {
+	allOf, ok := node["allOf"].([]interface{})
+	if !ok {
+		return node, nil
+	}
+
+	merged := shallowCopySchemaNode(node)
+	delete(merged, "allOf")
+
+	for _, entry := range allOf {
+		branch, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		branch, err := resolveSchemaNode(resolver, branch)
+		if err != nil {
+			return nil, err
+		}
+		branch, err = mergeAllOf(resolver, branch)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeSchemaNodeInto(merged, branch)
+	}
+
+	return merged, nil
+}
+
+func shallowCopySchemaNode(node map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(node))
+	for k, v := range node {
+		out[k] = v
+	}
+	return out
+}
+
+// mergeSchemaNodeInto merges `branch` into `base`, with `base` winning any
+// conflicting sibling keyword. Properties that appear on both sides are
+// themselves deep-merged (recursively, via mergeSchemaNodeInto), rather than
+// letting the first branch's definition shadow the second's, since DCOS
+// schemas commonly express "base schema + refinement" as two allOf branches
+// that both touch the same nested property.
+func mergeSchemaNodeInto(base, branch map[string]interface{}) map[string]interface{} {
+	out := shallowCopySchemaNode(base)
+
+	if branchProps, ok := branch["properties"].(map[string]interface{}); ok {
+		baseProps, _ := out["properties"].(map[string]interface{})
+		mergedProps := shallowCopySchemaNode(baseProps)
+		for key, value := range branchProps {
+			branchPropSchema, ok := value.(map[string]interface{})
+			if !ok {
+				if _, exists := mergedProps[key]; !exists {
+					mergedProps[key] = value
+				}
+				continue
+			}
+			if basePropSchema, exists := mergedProps[key].(map[string]interface{}); exists {
+				mergedProps[key] = mergeSchemaNodeInto(basePropSchema, branchPropSchema)
+			} else {
+				mergedProps[key] = branchPropSchema
+			}
+		}
+		out["properties"] = mergedProps
+	}
+
+	if branchReq, ok := branch["required"].([]interface{}); ok {
+		baseReq, _ := out["required"].([]interface{})
+		out["required"] = unionStringElements(baseReq, branchReq)
+	}
+
+	for key, value := range branch {
+		if key == "properties" || key == "required" {
+			continue
+		}
+		if _, exists := out[key]; !exists {
+			out[key] = value
+		}
+	}
+
+	return out
+}
+
+func unionStringElements(a, b []interface{}) []interface{} {
+	seen := make(map[string]bool, len(a)+len(b))
+	out := make([]interface{}, 0, len(a)+len(b))
+	for _, list := range [][]interface{}{a, b} {
+		for _, v := range list {
+			s, ok := v.(string)
+			if !ok || seen[s] {
+				continue
+			}
+			seen[s] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+/**
+ * Walk a {type: "object"} schema entry and return its default values along
+ * with the set of properties it requires.
+ */
+func defaultFromSchemaObject(resolver *schemaResolver, input map[string]interface{}) (*SchemaDefaults, error) {
+	input, err := resolveSchemaNode(resolver, input)
+	if err != nil {
+		return nil, err
+	}
+	input, err = mergeAllOf(resolver, input)
+	if err != nil {
+		return nil, err
+	}
+
+	varType := getSchemaNodeType(input)
+	if varType != "object" {
+		return nil, fmt.Errorf("Trying to process a non-object as object")
+	}
+
+	result := &SchemaDefaults{
+		Defaults: make(map[string]interface{}),
+		Required: make(map[string]bool),
+	}
+
+	if required, ok := input["required"].([]interface{}); ok {
+		for _, r := range required {
+			if s, ok := r.(string); ok {
+				result.Required[s] = true
+			}
+		}
+	}
+
+	props, ok := input["properties"].(map[string]interface{})
+	if !ok {
+		return result, nil
+	}
+
+	for key, value := range props {
+		valueMap, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		defaultValue, err := defaultFromSchemaValue(resolver, valueMap, result.Required[key])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", key, err.Error())
+		}
+		if defaultValue != nil {
+			result.Defaults[key] = defaultValue
+		}
+	}
+
+	return result, nil
+}
+
+/**
+ * Walk a {type: "*"} schema entry with a default value and return it.
+ * Otherwise returns `nil` if a default value is missing. `required`
+ * indicates whether the parent object requires this property, which
+ * matters for the enum fallback below.
+ */
+func defaultFromSchemaValue(resolver *schemaResolver, input map[string]interface{}, required bool) (interface{}, error) {
+	input, err := resolveSchemaNode(resolver, input)
+	if err != nil {
+		return nil, err
+	}
+	input, err = mergeAllOf(resolver, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if value, ok, err := defaultFromSchemaComposition(resolver, input); err != nil {
+		return nil, err
+	} else if ok {
+		return value, nil
+	}
+
+	switch getSchemaNodeType(input) {
+	case "object":
+		nested, err := defaultFromSchemaObject(resolver, input)
+		if err != nil {
+			return nil, err
+		}
+		if len(nested.Defaults) == 0 {
+			return nil, nil
+		}
+		return nested.Defaults, nil
+
+	case "array":
+		return defaultFromSchemaArray(resolver, input)
+	}
+
+	if defaultValue, ok := input["default"]; ok {
+		return defaultValue, nil
+	}
+
+	if required {
+		if enumValue, ok := firstEnumValue(input); ok {
+			return enumValue, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// defaultFromSchemaComposition picks a default out of a "oneOf"/"anyOf"
+// list: the branch marked with "x-default-branch": true wins if present,
+// otherwise the first branch that supplies a default is used. If neither
+// applies, (nil, false, nil) is returned so the caller can fall back to its
+// own sibling "default" keyword.
+func defaultFromSchemaComposition(resolver *schemaResolver, input map[string]interface{}) (interface{}, bool, error) {
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		branches, ok := input[keyword].([]interface{})
+		if !ok || len(branches) == 0 {
+			continue
+		}
+
+		var markedValue interface{}
+		marked := false
+		var firstValue interface{}
+		first := false
+
+		for _, entry := range branches {
+			branch, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			branch, err := resolveSchemaNode(resolver, branch)
+			if err != nil {
+				return nil, false, err
+			}
+
+			value, err := defaultFromSchemaValue(resolver, branch, false)
+			if err != nil {
+				return nil, false, err
+			}
+
+			if isDefaultBranch, _ := branch["x-default-branch"].(bool); isDefaultBranch && !marked {
+				markedValue, marked = value, true
+			}
+			if value != nil && !first {
+				firstValue, first = value, true
+			}
+		}
+
+		if marked {
+			return markedValue, true, nil
+		}
+		if first {
+			return firstValue, true, nil
+		}
+		return nil, false, nil
+	}
+
+	return nil, false, nil
+}
+
+// defaultFromSchemaArray returns the "default" of an array node if present,
+// otherwise builds one out of "items.default" as long as "minItems"
+// requires at least one element.
+func defaultFromSchemaArray(resolver *schemaResolver, input map[string]interface{}) (interface{}, error) {
+	if defaultValue, ok := input["default"]; ok {
+		return defaultValue, nil
+	}
+
+	minItems := 0
+	if v, ok := input["minItems"].(float64); ok {
+		minItems = int(v)
+	}
+	if minItems <= 0 {
+		return nil, nil
+	}
+
+	items, ok := input["items"].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	itemDefault, err := defaultFromSchemaValue(resolver, items, false)
+	if err != nil {
+		return nil, err
+	}
+	if itemDefault == nil {
+		return nil, nil
+	}
+
+	result := make([]interface{}, minItems)
+	for i := range result {
+		result[i] = itemDefault
+	}
+	return result, nil
+}
+
+// firstEnumValue returns the first entry of an "enum" list, if any.
+func firstEnumValue(input map[string]interface{}) (interface{}, bool) {
+	enum, ok := input["enum"].([]interface{})
+	if !ok || len(enum) == 0 {
+		return nil, false
+	}
+	return enum[0], true
+}
@@ -0,0 +1,151 @@
+package util
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func mustSchema(t *testing.T, raw string) map[string]interface{} {
+	t.Helper()
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &schema); err != nil {
+		t.Fatalf("invalid test schema: %s", err.Error())
+	}
+	return schema
+}
+
+func TestDefaultJSONFromSchemaResolvesLocalRef(t *testing.T) {
+	schema := mustSchema(t, `{
+		"type": "object",
+		"properties": {
+			"service": {
+				"type": "object",
+				"properties": {
+					"instances": {"$ref": "#/definitions/Instances"}
+				}
+			}
+		},
+		"definitions": {
+			"Instances": {"type": "integer", "default": 3}
+		}
+	}`)
+
+	result, err := DefaultJSONFromSchema(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	want := map[string]map[string]interface{}{
+		"service": {"instances": float64(3)},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("got %#v, want %#v", result, want)
+	}
+}
+
+func TestDefaultJSONFromSchemaDetectsRefCycle(t *testing.T) {
+	schema := mustSchema(t, `{
+		"type": "object",
+		"properties": {
+			"service": {"$ref": "#/properties/service"}
+		}
+	}`)
+
+	if _, err := DefaultJSONFromSchema(schema); err == nil {
+		t.Fatal("expected a cyclic $ref to be reported as an error")
+	}
+}
+
+func TestDefaultJSONFromSchemaMergesAllOf(t *testing.T) {
+	schema := mustSchema(t, `{
+		"type": "object",
+		"properties": {
+			"service": {
+				"allOf": [
+					{"type": "object", "properties": {"name": {"type": "string", "default": "a"}}},
+					{"type": "object", "properties": {"mode": {"type": "string"}}, "required": ["mode"]}
+				],
+				"properties": {"mode": {"type": "string", "enum": ["docker", "mesos"]}}
+			}
+		}
+	}`)
+
+	result, err := DefaultJSONFromSchema(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	want := map[string]map[string]interface{}{
+		"service": {"name": "a", "mode": "docker"},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("got %#v, want %#v", result, want)
+	}
+}
+
+func TestDefaultJSONFromSchemaOneOfDefaultBranch(t *testing.T) {
+	schema := mustSchema(t, `{
+		"type": "object",
+		"properties": {
+			"service": {
+				"type": "object",
+				"properties": {
+					"strategy": {
+						"oneOf": [
+							{"type": "string", "x-default-branch": true, "default": "blue-green"},
+							{"type": "string", "default": "canary"}
+						]
+					}
+				}
+			}
+		}
+	}`)
+
+	result, err := DefaultJSONFromSchema(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	want := map[string]map[string]interface{}{
+		"service": {"strategy": "blue-green"},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("got %#v, want %#v", result, want)
+	}
+}
+
+func TestDefaultJSONFromSchemaWithRequiredExposesRequiredSet(t *testing.T) {
+	schema := mustSchema(t, `{
+		"type": "object",
+		"properties": {
+			"service": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string", "default": "myapp"},
+					"mode": {"type": "string"}
+				},
+				"required": ["mode"]
+			}
+		}
+	}`)
+
+	defaults, required, err := DefaultJSONFromSchemaWithRequired(schema, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	wantDefaults := map[string]map[string]interface{}{
+		"service": {"name": "myapp"},
+	}
+	if !reflect.DeepEqual(defaults, wantDefaults) {
+		t.Fatalf("got defaults %#v, want %#v", defaults, wantDefaults)
+	}
+
+	wantRequired := map[string]map[string]bool{
+		"service": {"mode": true},
+	}
+	if !reflect.DeepEqual(required, wantRequired) {
+		t.Fatalf("got required %#v, want %#v", required, wantRequired)
+	}
+}
@@ -0,0 +1,270 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+/**
+ * SchemaHints tells HashDict and GetDictDiff which JSON-Pointer paths hold
+ * semantically unordered arrays (labels, port lists, constraint tuples, ...)
+ * so that they are compared and hashed as sets rather than ordered lists,
+ * modeled on Terraform's schema.Set / schema.Set.F.
+ */
+type SchemaHints struct {
+	// Sets maps a JSON-Pointer path (e.g. "/service/labels") to the hash
+	// function used to bucket its elements. A nil function falls back to
+	// HashDict for object elements and fmt.Sprint for everything else.
+	Sets map[string]func(interface{}) string
+}
+
+func (h *SchemaHints) isSet(path string) bool {
+	if h == nil || h.Sets == nil {
+		return false
+	}
+	_, ok := h.Sets[path]
+	return ok
+}
+
+func (h *SchemaHints) hashFuncFor(path string) func(interface{}) string {
+	if h != nil && h.Sets != nil {
+		if fn, ok := h.Sets[path]; ok && fn != nil {
+			return fn
+		}
+	}
+	return defaultSetElementHash
+}
+
+func defaultSetElementHash(value interface{}) string {
+	if m, ok := value.(map[string]interface{}); ok {
+		if hash, err := HashDict(m); err == nil {
+			return hash
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+/**
+ * HashDictWithHints behaves like HashDict, but normalizes the element order
+ * of any array found at a path in `hints` before hashing, so that reordering
+ * a set-typed array does not change the resulting hash.
+ */
+func HashDictWithHints(input map[string]interface{}, hints *SchemaHints) (string, error) {
+	// JSON serializer serializes the keys in alphabetical order, so we are
+	// certain that every time the result will be the same
+	canonical := canonicalizeSets("", CleanupJSON(input), hints)
+	bytes, err := json.Marshal(canonical)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(bytes)
+	return fmt.Sprintf("%x", sum), nil
+}
+
+// canonicalizeSets walks `node`, sorting any array found at a path marked
+// as a set in `hints` by its element hash so that the JSON encoding no
+// longer depends on the original element order.
+func canonicalizeSets(path string, node interface{}, hints *SchemaHints) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			out[key] = canonicalizeSets(path+"/"+escapeJSONPointerToken(key), value, hints)
+		}
+		return out
+
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			out[i] = canonicalizeSets(path, value, hints)
+		}
+		if hints.isSet(path) {
+			hashFn := hints.hashFuncFor(path)
+			sort.Slice(out, func(i, j int) bool {
+				return hashFn(out[i]) < hashFn(out[j])
+			})
+		}
+		return out
+
+	default:
+		return node
+	}
+}
+
+/**
+ * GetDictDiffWithHints behaves like GetDictDiff, but compares any array
+ * found at a path in `hints` as a multiset (grouped by element hash)
+ * instead of an ordered list, so that the DCOS API reordering e.g. a
+ * labels array does not produce a spurious diff.
+ */
+func GetDictDiffWithHints(reference map[string]interface{}, input map[string]interface{}, hints *SchemaHints) map[string]interface{} {
+	return getDictDiffAt("", reference, input, hints)
+}
+
+func getDictDiffAt(path string, reference map[string]interface{}, input map[string]interface{}, hints *SchemaHints) map[string]interface{} {
+	ret := make(map[string]interface{})
+	for k, v := range input {
+		childPath := path + "/" + escapeJSONPointerToken(k)
+		if rv, ok := reference[k]; ok {
+			replace, nv := getValueDiffAt(childPath, rv, v, hints)
+			if replace {
+				ret[k] = nv
+			}
+		} else {
+			// If the value does not exist in reference, it's new, and we
+			// should include it.
+			ret[k] = v
+		}
+	}
+
+	return ret
+}
+
+/**
+ * getValueDiffAt compares a reference and an input value at `path` and
+ * checks if the input value should be included in the diff or not
+ */
+func getValueDiffAt(path string, reference interface{}, input interface{}, hints *SchemaHints) (bool, interface{}) {
+	// Type change always indicates a replacement
+	if reflect.TypeOf(reference) != reflect.TypeOf(input) {
+		return true, input
+	}
+
+	// Otherwise, replacement depends on the underlying type
+	switch v := reference.(type) {
+	case map[string]interface{}:
+		// Maps are compared element-wise
+		diff := getDictDiffAt(path, v, input.(map[string]interface{}), hints)
+		if len(diff) == 0 {
+			return false, nil
+		}
+		return true, diff
+
+	case []interface{}:
+		ia := input.([]interface{})
+
+		if hints.isSet(path) {
+			// Set-typed arrays are compared as multisets, bucketed by hash
+			if setsEqual(v, ia, hints.hashFuncFor(path)) {
+				return false, nil
+			}
+			return true, input
+		}
+
+		// Ordinary arrays are compared against their content match
+		if len(v) != len(ia) {
+			return true, input
+		}
+		isEqual := true
+		for i, iv := range v {
+			if iv != ia[i] {
+				isEqual = false
+				break
+			}
+		}
+		if !isEqual {
+			return true, input
+		}
+
+	default:
+		// Dynamic types are compared according to their dynamic value
+		if v != input {
+			return true, input
+		}
+	}
+
+	// By default do not include this item
+	return false, nil
+}
+
+// setsEqual checks whether a and b contain the same elements by hash,
+// irrespective of order or duplicate placement.
+func setsEqual(a, b []interface{}, hashFn func(interface{}) string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	counts := make(map[string]int, len(a))
+	for _, v := range a {
+		counts[hashFn(v)]++
+	}
+	for _, v := range b {
+		h := hashFn(v)
+		counts[h]--
+		if counts[h] < 0 {
+			return false
+		}
+	}
+	for _, c := range counts {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+ * SchemaHintsFromJSONSchema infers set-ness from a JSON Schema document:
+ * an array node is treated as a set when it carries `"x-terraform-set": true`
+ * or `"uniqueItems": true`. Nested object properties and array items are
+ * walked recursively, following local "$ref" pointers and "allOf" branches
+ * the same way DefaultJSONFromSchema does.
+ */
+func SchemaHintsFromJSONSchema(schema map[string]interface{}) (*SchemaHints, error) {
+	resolver := newSchemaResolver(schema, nil)
+	hints := &SchemaHints{Sets: make(map[string]func(interface{}) string)}
+	if err := collectSetHints(resolver, "", schema, hints); err != nil {
+		return nil, err
+	}
+	return hints, nil
+}
+
+func collectSetHints(resolver *schemaResolver, path string, node map[string]interface{}, hints *SchemaHints) error {
+	node, err := resolveSchemaNode(resolver, node)
+	if err != nil {
+		return err
+	}
+	node, err = mergeAllOf(resolver, node)
+	if err != nil {
+		return err
+	}
+
+	switch getSchemaNodeType(node) {
+	case "object":
+		props, ok := node["properties"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for key, value := range props {
+			propSchema, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if err := collectSetHints(resolver, path+"/"+escapeJSONPointerToken(key), propSchema, hints); err != nil {
+				return err
+			}
+		}
+
+	case "array":
+		isSet, _ := node["x-terraform-set"].(bool)
+		if !isSet {
+			isSet, _ = node["uniqueItems"].(bool)
+		}
+		if isSet {
+			hints.Sets[path] = nil
+		}
+		if items, ok := node["items"].(map[string]interface{}); ok {
+			// Items share the array's own path: there is no concrete index
+			// to key nested hints on for an unordered collection.
+			if err := collectSetHints(resolver, path, items, hints); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package util
+
+import "testing"
+
+func TestHashDictWithHintsIgnoresSetElementOrder(t *testing.T) {
+	hints := &SchemaHints{Sets: map[string]func(interface{}) string{"/labels": nil}}
+
+	a := map[string]interface{}{"labels": []interface{}{"a", "b", "c"}}
+	b := map[string]interface{}{"labels": []interface{}{"c", "a", "b"}}
+
+	hashA, err := HashDictWithHints(a, hints)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	hashB, err := HashDictWithHints(b, hints)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if hashA != hashB {
+		t.Fatalf("expected reordering a set-typed array to not change the hash: %s != %s", hashA, hashB)
+	}
+}
+
+func TestHashDictWithoutHintsIsOrderSensitive(t *testing.T) {
+	a := map[string]interface{}{"labels": []interface{}{"a", "b", "c"}}
+	b := map[string]interface{}{"labels": []interface{}{"c", "a", "b"}}
+
+	hashA, err := HashDictWithHints(a, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	hashB, err := HashDictWithHints(b, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if hashA == hashB {
+		t.Fatal("expected reordering an ordinary array to change the hash")
+	}
+}
+
+func TestGetDictDiffWithHintsTreatsSetAsMultiset(t *testing.T) {
+	hints := &SchemaHints{Sets: map[string]func(interface{}) string{"/labels": nil}}
+
+	reference := map[string]interface{}{"labels": []interface{}{"a", "b", "c"}}
+	input := map[string]interface{}{"labels": []interface{}{"c", "a", "b"}}
+
+	diff := GetDictDiffWithHints(reference, input, hints)
+	if len(diff) != 0 {
+		t.Fatalf("expected no diff for a reordered set, got %#v", diff)
+	}
+}
+
+func TestGetDictDiffWithHintsReportsActualSetChange(t *testing.T) {
+	hints := &SchemaHints{Sets: map[string]func(interface{}) string{"/labels": nil}}
+
+	reference := map[string]interface{}{"labels": []interface{}{"a", "b"}}
+	input := map[string]interface{}{"labels": []interface{}{"a", "c"}}
+
+	diff := GetDictDiffWithHints(reference, input, hints)
+	if _, ok := diff["labels"]; !ok {
+		t.Fatalf("expected a diff entry for a changed set, got %#v", diff)
+	}
+}
+
+func TestSchemaHintsFromJSONSchemaInfersSets(t *testing.T) {
+	schema := mustSchema(t, `{
+		"type": "object",
+		"properties": {
+			"labels": {"type": "array", "uniqueItems": true, "items": {"type": "string"}},
+			"names": {"type": "array", "x-terraform-set": true, "items": {"type": "string"}},
+			"ordered": {"type": "array", "items": {"type": "string"}}
+		}
+	}`)
+
+	hints, err := SchemaHintsFromJSONSchema(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if !hints.isSet("/labels") {
+		t.Fatal("expected /labels to be inferred as a set via uniqueItems")
+	}
+	if !hints.isSet("/names") {
+		t.Fatal("expected /names to be inferred as a set via x-terraform-set")
+	}
+	if hints.isSet("/ordered") {
+		t.Fatal("expected /ordered to not be treated as a set")
+	}
+}
@@ -0,0 +1,133 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/**
+ * SchemaRefLoader loads an external JSON Schema document referenced by a
+ * cross-file `$ref` such as "common.json#/definitions/Foo". Schema walkers
+ * that only ever see local "#/..." refs can pass a nil loader.
+ */
+type SchemaRefLoader func(file string) (map[string]interface{}, error)
+
+/**
+ * schemaResolver resolves "$ref" pointers against a root JSON Schema
+ * document, optionally pulling in external documents through a
+ * SchemaRefLoader. Resolved nodes are cached, and a ref that is resolved
+ * while it is still being resolved (a schema cycle) is reported as an error
+ * instead of recursing forever.
+ */
+type schemaResolver struct {
+	loader   SchemaRefLoader
+	docs     map[string]map[string]interface{} // file -> parsed document, "" is the root
+	cache    map[string]map[string]interface{} // ref -> resolved node
+	visiting map[string]bool
+}
+
+func newSchemaResolver(root map[string]interface{}, loader SchemaRefLoader) *schemaResolver {
+	return &schemaResolver{
+		loader:   loader,
+		docs:     map[string]map[string]interface{}{"": root},
+		cache:    make(map[string]map[string]interface{}),
+		visiting: make(map[string]bool),
+	}
+}
+
+/**
+ * resolve follows `ref` (e.g. "#/definitions/Foo" or "common.json#/definitions/Foo")
+ * and returns the schema node it points at.
+ */
+func (r *schemaResolver) resolve(ref string) (map[string]interface{}, error) {
+	if node, ok := r.cache[ref]; ok {
+		return node, nil
+	}
+	if r.visiting[ref] {
+		return nil, fmt.Errorf("cyclic $ref detected: %s", ref)
+	}
+	r.visiting[ref] = true
+	defer delete(r.visiting, ref)
+
+	file, pointer := splitRef(ref)
+	doc, ok := r.docs[file]
+	if !ok {
+		if r.loader == nil {
+			return nil, fmt.Errorf("$ref %q points to an external file, but no loader was configured", ref)
+		}
+		loaded, err := r.loader(file)
+		if err != nil {
+			return nil, fmt.Errorf("$ref %q: %s", ref, err.Error())
+		}
+		doc = loaded
+		r.docs[file] = doc
+	}
+
+	node, err := resolveJSONPointer(doc, pointer)
+	if err != nil {
+		return nil, fmt.Errorf("$ref %q: %s", ref, err.Error())
+	}
+
+	// The resolved node may itself be a $ref alias, so chase that too.
+	if nestedRef, ok := node["$ref"].(string); ok {
+		resolved, err := r.resolve(nestedRef)
+		if err != nil {
+			return nil, err
+		}
+		r.cache[ref] = resolved
+		return resolved, nil
+	}
+
+	r.cache[ref] = node
+	return node, nil
+}
+
+// splitRef splits a $ref into its file component and its JSON-Pointer
+// fragment. A purely local ref ("#/a/b") yields an empty file.
+func splitRef(ref string) (string, string) {
+	idx := strings.IndexByte(ref, '#')
+	if idx < 0 {
+		return ref, ""
+	}
+	return ref[:idx], ref[idx+1:]
+}
+
+// resolveJSONPointer walks a RFC 6901 JSON Pointer (with or without its
+// leading "#") against doc and returns the object node it points at.
+func resolveJSONPointer(doc map[string]interface{}, pointer string) (map[string]interface{}, error) {
+	pointer = strings.TrimPrefix(pointer, "#")
+	var node interface{} = doc
+	if pointer == "" || pointer == "/" {
+		if m, ok := node.(map[string]interface{}); ok {
+			return m, nil
+		}
+		return nil, fmt.Errorf("pointer does not resolve to an object")
+	}
+
+	for _, rawToken := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token := unescapeJSONPointerToken(rawToken)
+		switch v := node.(type) {
+		case map[string]interface{}:
+			next, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("token %q not found", token)
+			}
+			node = next
+		case []interface{}:
+			idx, err := strconv.Atoi(token)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, fmt.Errorf("token %q is not a valid array index", token)
+			}
+			node = v[idx]
+		default:
+			return nil, fmt.Errorf("token %q has no child to descend into", token)
+		}
+	}
+
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("pointer does not resolve to an object")
+	}
+	return m, nil
+}
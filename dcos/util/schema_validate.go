@@ -0,0 +1,387 @@
+package util
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+)
+
+/**
+ * ValidationError describes a single JSON Schema violation: the
+ * JSON-Pointer path of the offending value, the keyword that rejected it,
+ * and a human-readable message.
+ */
+type ValidationError struct {
+	Path    string
+	Keyword string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+/**
+ * ValidateAgainstSchema validates `value` against the draft-07 keywords the
+ * DCOS Cosmos catalog actually uses ("type", "required", "properties",
+ * "additionalProperties", "patternProperties", "items", "minItems"/"maxItems"/
+ * "uniqueItems", "minimum"/"maximum"/"exclusiveMinimum"/"exclusiveMaximum",
+ * "minLength"/"maxLength"/"pattern", "enum", "const" and "allOf"/"anyOf"/
+ * "oneOf"/"not"), returning every violation instead of stopping at the
+ * first one so a caller can surface them all at once. Local "$ref" pointers
+ * are resolved with the same resolver DefaultJSONFromSchema uses.
+ */
+func ValidateAgainstSchema(value interface{}, schema map[string]interface{}) []ValidationError {
+	resolver := newSchemaResolver(schema, nil)
+	var errs []ValidationError
+	validateNode(resolver, "", value, schema, &errs)
+	return errs
+}
+
+func validateNode(resolver *schemaResolver, path string, value interface{}, schema map[string]interface{}, errs *[]ValidationError) {
+	schema, err := resolveSchemaNode(resolver, schema)
+	if err != nil {
+		*errs = append(*errs, ValidationError{Path: path, Keyword: "$ref", Message: err.Error()})
+		return
+	}
+
+	if constValue, ok := schema["const"]; ok {
+		if !reflect.DeepEqual(value, constValue) {
+			*errs = append(*errs, ValidationError{Path: path, Keyword: "const", Message: fmt.Sprintf("must equal %v", constValue)})
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		if !enumContains(enum, value) {
+			*errs = append(*errs, ValidationError{Path: path, Keyword: "enum", Message: fmt.Sprintf("must be one of: %s", joinEnumChoices(enum))})
+		}
+	}
+
+	if !validateTypeKeyword(path, value, schema, errs) {
+		// A declared type that does not match the instance makes the
+		// type-specific checks below meaningless.
+		validateComposition(resolver, path, value, schema, errs)
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		validateObject(resolver, path, v, schema, errs)
+	case []interface{}:
+		validateArray(resolver, path, v, schema, errs)
+	case string:
+		validateString(path, v, schema, errs)
+	case float64:
+		validateNumber(path, v, schema, errs)
+	}
+
+	validateComposition(resolver, path, value, schema, errs)
+}
+
+// validateTypeKeyword checks the "type" keyword, if present, and reports a
+// violation. It returns false when the instance does not match the
+// declared type so the caller can skip type-specific keyword checks.
+func validateTypeKeyword(path string, value interface{}, schema map[string]interface{}, errs *[]ValidationError) bool {
+	typeValue, ok := schema["type"]
+	if !ok {
+		return true
+	}
+
+	switch t := typeValue.(type) {
+	case string:
+		if valueMatchesType(value, t) {
+			return true
+		}
+		*errs = append(*errs, ValidationError{Path: path, Keyword: "type", Message: fmt.Sprintf("must be of type %q, got %s", t, jsonTypeOf(value))})
+		return false
+
+	case []interface{}:
+		for _, candidate := range t {
+			if ts, ok := candidate.(string); ok && valueMatchesType(value, ts) {
+				return true
+			}
+		}
+		*errs = append(*errs, ValidationError{Path: path, Keyword: "type", Message: fmt.Sprintf("must be one of type %v, got %s", t, jsonTypeOf(value))})
+		return false
+	}
+
+	return true
+}
+
+func valueMatchesType(value interface{}, varType string) bool {
+	switch varType {
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "null":
+		return value == nil
+	default:
+		return true
+	}
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func enumContains(enum []interface{}, value interface{}) bool {
+	for _, candidate := range enum {
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func childPath(path string, token string) string {
+	return path + "/" + escapeJSONPointerToken(token)
+}
+
+func validateObject(resolver *schemaResolver, path string, obj map[string]interface{}, schema map[string]interface{}, errs *[]ValidationError) {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, ok := r.(string)
+			if !ok {
+				continue
+			}
+			if _, present := obj[name]; !present {
+				*errs = append(*errs, ValidationError{
+					Path:    childPath(path, name),
+					Keyword: "required",
+					Message: fmt.Sprintf("%q is required", name),
+				})
+			}
+		}
+	}
+
+	props, _ := schema["properties"].(map[string]interface{})
+	matched := make(map[string]bool, len(obj))
+
+	for key, propSchemaRaw := range props {
+		propSchema, ok := propSchemaRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if v, present := obj[key]; present {
+			matched[key] = true
+			validateNode(resolver, childPath(path, key), v, propSchema, errs)
+		}
+	}
+
+	if patternProps, ok := schema["patternProperties"].(map[string]interface{}); ok {
+		for pattern, propSchemaRaw := range patternProps {
+			propSchema, ok := propSchemaRaw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				*errs = append(*errs, ValidationError{Path: path, Keyword: "patternProperties", Message: fmt.Sprintf("invalid pattern %q: %s", pattern, err.Error())})
+				continue
+			}
+			for key, v := range obj {
+				if re.MatchString(key) {
+					matched[key] = true
+					validateNode(resolver, childPath(path, key), v, propSchema, errs)
+				}
+			}
+		}
+	}
+
+	switch additional := schema["additionalProperties"].(type) {
+	case bool:
+		if !additional {
+			for key := range obj {
+				if !matched[key] {
+					*errs = append(*errs, ValidationError{
+						Path:    childPath(path, key),
+						Keyword: "additionalProperties",
+						Message: fmt.Sprintf("additional property %q is not allowed", key),
+					})
+				}
+			}
+		}
+	case map[string]interface{}:
+		for key, v := range obj {
+			if !matched[key] {
+				validateNode(resolver, childPath(path, key), v, additional, errs)
+			}
+		}
+	}
+}
+
+func validateArray(resolver *schemaResolver, path string, arr []interface{}, schema map[string]interface{}, errs *[]ValidationError) {
+	if minItems, ok := schema["minItems"].(float64); ok && float64(len(arr)) < minItems {
+		*errs = append(*errs, ValidationError{Path: path, Keyword: "minItems", Message: fmt.Sprintf("must contain at least %v items", minItems)})
+	}
+	if maxItems, ok := schema["maxItems"].(float64); ok && float64(len(arr)) > maxItems {
+		*errs = append(*errs, ValidationError{Path: path, Keyword: "maxItems", Message: fmt.Sprintf("must contain at most %v items", maxItems)})
+	}
+	if unique, ok := schema["uniqueItems"].(bool); ok && unique && !arrayItemsUnique(arr) {
+		*errs = append(*errs, ValidationError{Path: path, Keyword: "uniqueItems", Message: "items must be unique"})
+	}
+
+	switch items := schema["items"].(type) {
+	case map[string]interface{}:
+		for i, v := range arr {
+			validateNode(resolver, fmt.Sprintf("%s/%d", path, i), v, items, errs)
+		}
+
+	case []interface{}:
+		// Tuple validation: items[i] validates arr[i]; elements beyond the
+		// tuple are checked against "additionalItems" when present.
+		for i, v := range arr {
+			if i < len(items) {
+				if itemSchema, ok := items[i].(map[string]interface{}); ok {
+					validateNode(resolver, fmt.Sprintf("%s/%d", path, i), v, itemSchema, errs)
+				}
+				continue
+			}
+			switch additional := schema["additionalItems"].(type) {
+			case map[string]interface{}:
+				validateNode(resolver, fmt.Sprintf("%s/%d", path, i), v, additional, errs)
+			case bool:
+				if !additional {
+					*errs = append(*errs, ValidationError{
+						Path:    fmt.Sprintf("%s/%d", path, i),
+						Keyword: "additionalItems",
+						Message: "additional items are not allowed",
+					})
+				}
+			}
+		}
+	}
+}
+
+func arrayItemsUnique(arr []interface{}) bool {
+	seen := make(map[string]bool, len(arr))
+	for _, v := range arr {
+		h := defaultSetElementHash(v)
+		if seen[h] {
+			return false
+		}
+		seen[h] = true
+	}
+	return true
+}
+
+func validateString(path string, s string, schema map[string]interface{}, errs *[]ValidationError) {
+	length := len([]rune(s))
+	if minLen, ok := schema["minLength"].(float64); ok && float64(length) < minLen {
+		*errs = append(*errs, ValidationError{Path: path, Keyword: "minLength", Message: fmt.Sprintf("must be at least %v characters long", minLen)})
+	}
+	if maxLen, ok := schema["maxLength"].(float64); ok && float64(length) > maxLen {
+		*errs = append(*errs, ValidationError{Path: path, Keyword: "maxLength", Message: fmt.Sprintf("must be at most %v characters long", maxLen)})
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			*errs = append(*errs, ValidationError{Path: path, Keyword: "pattern", Message: fmt.Sprintf("invalid pattern %q: %s", pattern, err.Error())})
+		} else if !re.MatchString(s) {
+			*errs = append(*errs, ValidationError{Path: path, Keyword: "pattern", Message: fmt.Sprintf("must match pattern %q", pattern)})
+		}
+	}
+}
+
+func validateNumber(path string, n float64, schema map[string]interface{}, errs *[]ValidationError) {
+	if min, ok := schema["minimum"].(float64); ok && n < min {
+		*errs = append(*errs, ValidationError{Path: path, Keyword: "minimum", Message: fmt.Sprintf("must be >= %v", min)})
+	}
+	if max, ok := schema["maximum"].(float64); ok && n > max {
+		*errs = append(*errs, ValidationError{Path: path, Keyword: "maximum", Message: fmt.Sprintf("must be <= %v", max)})
+	}
+	if exMin, ok := schema["exclusiveMinimum"].(float64); ok && n <= exMin {
+		*errs = append(*errs, ValidationError{Path: path, Keyword: "exclusiveMinimum", Message: fmt.Sprintf("must be > %v", exMin)})
+	}
+	if exMax, ok := schema["exclusiveMaximum"].(float64); ok && n >= exMax {
+		*errs = append(*errs, ValidationError{Path: path, Keyword: "exclusiveMaximum", Message: fmt.Sprintf("must be < %v", exMax)})
+	}
+}
+
+func validateComposition(resolver *schemaResolver, path string, value interface{}, schema map[string]interface{}, errs *[]ValidationError) {
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			if subSchema, ok := sub.(map[string]interface{}); ok {
+				validateNode(resolver, path, value, subSchema, errs)
+			}
+		}
+	}
+
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		if !anyBranchMatches(resolver, path, value, anyOf) {
+			*errs = append(*errs, ValidationError{Path: path, Keyword: "anyOf", Message: "must match at least one of the listed schemas"})
+		}
+	}
+
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		if matches := countMatchingBranches(resolver, path, value, oneOf); matches != 1 {
+			*errs = append(*errs, ValidationError{Path: path, Keyword: "oneOf", Message: fmt.Sprintf("must match exactly one of the listed schemas (matched %d)", matches)})
+		}
+	}
+
+	if not, ok := schema["not"].(map[string]interface{}); ok {
+		if len(validateBranch(resolver, path, value, not)) == 0 {
+			*errs = append(*errs, ValidationError{Path: path, Keyword: "not", Message: "must not match the given schema"})
+		}
+	}
+}
+
+func validateBranch(resolver *schemaResolver, path string, value interface{}, schema map[string]interface{}) []ValidationError {
+	var errs []ValidationError
+	validateNode(resolver, path, value, schema, &errs)
+	return errs
+}
+
+func anyBranchMatches(resolver *schemaResolver, path string, value interface{}, branches []interface{}) bool {
+	for _, b := range branches {
+		if branchSchema, ok := b.(map[string]interface{}); ok {
+			if len(validateBranch(resolver, path, value, branchSchema)) == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func countMatchingBranches(resolver *schemaResolver, path string, value interface{}, branches []interface{}) int {
+	count := 0
+	for _, b := range branches {
+		if branchSchema, ok := b.(map[string]interface{}); ok {
+			if len(validateBranch(resolver, path, value, branchSchema)) == 0 {
+				count++
+			}
+		}
+	}
+	return count
+}